@@ -0,0 +1,61 @@
+package provider
+
+import (
+    "context"
+    "crypto/md5"
+    "crypto/sha256"
+    "crypto/sha512"
+    "encoding/hex"
+    "fmt"
+    "hash"
+    "io"
+    "strings"
+)
+
+// Checksum pins an expected digest for an ISO. Algorithm must be one of
+// "sha256", "sha512", or "md5", matching the values Proxmox's
+// download-url and storage APIs expect.
+type Checksum struct {
+    Algorithm string
+    Value     string
+}
+
+// newHasher returns a hash.Hash for the given Proxmox checksum algorithm string.
+func newHasher(algorithm string) (hash.Hash, error) {
+    switch algorithm {
+    case "sha256":
+        return sha256.New(), nil
+    case "sha512":
+        return sha512.New(), nil
+    case "md5":
+        return md5.New(), nil
+    default:
+        return nil, fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+    }
+}
+
+// verifyExistingISO re-hashes an ISO already present on a storage by
+// streaming its content through the Proxmox API and compares the digest
+// against checksum, so a corrupted cached ISO can be detected and
+// re-downloaded rather than silently booted.
+func (p *Provisioner) verifyExistingISO(ctx context.Context, node, storage, isoName string, checksum Checksum) (bool, error) {
+    hasher, err := newHasher(checksum.Algorithm)
+    if err != nil {
+        return false, err
+    }
+
+    volid := fmt.Sprintf("%s:iso/%s", storage, isoName)
+    path := fmt.Sprintf("/nodes/%s/storage/%s/content/%s", node, storage, volid)
+
+    r, err := p.proxmoxClient.GetStream(ctx, path)
+    if err != nil {
+        return false, fmt.Errorf("failed to stream ISO %q for verification: %w", isoName, err)
+    }
+    defer r.Close()
+
+    if _, err := io.Copy(hasher, r); err != nil {
+        return false, fmt.Errorf("failed to hash ISO %q: %w", isoName, err)
+    }
+
+    return strings.EqualFold(hex.EncodeToString(hasher.Sum(nil)), checksum.Value), nil
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+    "crypto/md5"
+    "crypto/sha256"
+    "crypto/sha512"
+    "testing"
+)
+
+func TestNewHasher(t *testing.T) {
+    tests := []struct {
+        algorithm string
+        size      int
+        wantErr   bool
+    }{
+        {algorithm: "sha256", size: sha256.Size},
+        {algorithm: "sha512", size: sha512.Size},
+        {algorithm: "md5", size: md5.Size},
+        {algorithm: "sha1", wantErr: true},
+        {algorithm: "", wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.algorithm, func(t *testing.T) {
+            h, err := newHasher(tt.algorithm)
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("newHasher(%q): expected error, got nil", tt.algorithm)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("newHasher(%q): unexpected error: %v", tt.algorithm, err)
+            }
+            if got := h.Size(); got != tt.size {
+                t.Errorf("newHasher(%q).Size() = %d, want %d", tt.algorithm, got, tt.size)
+            }
+        })
+    }
+}
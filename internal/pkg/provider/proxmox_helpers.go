@@ -5,50 +5,27 @@ import (
     "encoding/json"
     "fmt"
     "strings"
-
-    // proxmox client is used via Provisioner.proxmoxClient
 )
 
-// findISOStorageName queries Proxmox for storages on a node and returns
-// the first storage that advertises support for "iso" content.
+// findISOStorageName queries Proxmox for ISO-capable storages on a node and
+// asks p.storageSelector (FirstMatchSelector if unset) to pick one.
 func (p *Provisioner) findISOStorageName(ctx context.Context, node string) (string, error) {
-    // Fetch storage list for the node. Use a raw JSON container to be resilient
-    // against variability in the returned shape.
-    var raw json.RawMessage
-    if err := p.proxmoxClient.Get(ctx, fmt.Sprintf("/nodes/%s/storage", node), &raw); err != nil {
+    infos, err := p.listISOStorages(ctx, node)
+    if err != nil {
         return "", err
     }
 
-    // Try to unmarshal into a slice of generic objects
-    var items []map[string]any
-    if err := json.Unmarshal(raw, &items); err != nil {
-        return "", fmt.Errorf("failed to parse storage list: %w", err)
+    selector := p.storageSelector
+    if selector == nil {
+        selector = FirstMatchSelector{}
     }
 
-    for _, item := range items {
-        // storage name may be under "storage" or "name"
-        var name string
-        if s, ok := item["storage"].(string); ok {
-            name = s
-        } else if s, ok := item["name"].(string); ok {
-            name = s
-        }
-
-        if name == "" {
-            continue
-        }
-
-        // content can be array of strings
-        if content, ok := item["content"].([]any); ok {
-            for _, c := range content {
-                if cs, ok := c.(string); ok && cs == "iso" {
-                    return name, nil
-                }
-            }
-        }
+    name, err := selector.SelectStorage(infos)
+    if err != nil {
+        return "", fmt.Errorf("no ISO-capable storage found on node %s: %w", node, err)
     }
 
-    return "", fmt.Errorf("no ISO-capable storage found on node %s", node)
+    return name, nil
 }
 
 // storageHasISO checks whether the given storage on a node already contains
@@ -81,14 +58,32 @@ func (p *Provisioner) storageHasISO(ctx context.Context, node, storage, isoName
     return false, nil
 }
 
+// deleteStorageVolume removes a volume (e.g. "<storage>:iso/<name>") from a
+// node's storage. It's used to quarantine an ISO upload that failed
+// checksum verification so a later EnsureISO call can retry the upload
+// instead of treating the corrupt file as already present.
+func (p *Provisioner) deleteStorageVolume(ctx context.Context, node, storage, volid string) error {
+    path := fmt.Sprintf("/nodes/%s/storage/%s/content/%s", node, storage, volid)
+    if err := p.proxmoxClient.Delete(ctx, path, nil); err != nil {
+        return fmt.Errorf("failed to delete volume %q from %s/%s: %w", volid, node, storage, err)
+    }
+    return nil
+}
+
 // startStorageDownload triggers a storage download task for an ISO and returns the task UPID as string.
-func (p *Provisioner) startStorageDownload(ctx context.Context, node, storage, isoName, sourceURL string) (string, error) {
+// If checksum is non-nil, its Algorithm and Value are forwarded so Proxmox
+// validates the downloaded file server-side before making it available.
+func (p *Provisioner) startStorageDownload(ctx context.Context, node, storage, isoName, sourceURL string, checksum *Checksum) (string, error) {
     // Prepare parameters according to Proxmox storage download API
     params := map[string]string{
         "content":  "iso",
         "filename": isoName,
         "url":      sourceURL,
     }
+    if checksum != nil {
+        params["checksum"] = checksum.Value
+        params["checksum-algorithm"] = checksum.Algorithm
+    }
 
     // The go-proxmox client expects a target to unmarshal response into.
     // We'll unmarshal into a generic map and extract the UPID from returned data.
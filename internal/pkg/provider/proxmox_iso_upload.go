@@ -0,0 +1,124 @@
+package provider
+
+import (
+    "context"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "hash"
+    "io"
+    "os"
+    "strings"
+)
+
+// ISOSpec describes where an ISO can be obtained from: a URL Proxmox should
+// download directly, or a local path the caller already has on disk. Exactly
+// one of SourceURL or LocalPath is expected to be set.
+type ISOSpec struct {
+    // Name is the filename the ISO should be stored under, e.g. "talos-v1.6.0.iso".
+    Name string
+
+    // SourceURL, if set, is fetched by Proxmox itself via startStorageDownload.
+    SourceURL string
+
+    // LocalPath, if set, is uploaded from this host via uploadISOFromFile.
+    LocalPath string
+
+    // Checksum, if set, is verified against the ISO content. For SourceURL
+    // it is forwarded to Proxmox for server-side validation; for LocalPath
+    // it is computed client-side while streaming the upload.
+    Checksum *Checksum
+}
+
+// uploadISOFromFile streams a local ISO to Proxmox via the storage upload
+// endpoint, mirroring how Packer's Proxmox builder pushes locally-built ISOs
+// for air-gapped or not-yet-published images. It returns the task UPID. If
+// checksum is non-nil, the digest is computed while streaming and compared
+// once the upload completes.
+func (p *Provisioner) uploadISOFromFile(ctx context.Context, node, storage, filename string, r io.Reader, checksum *Checksum) (string, error) {
+    fields := map[string]string{
+        "content": "iso",
+    }
+
+    body := r
+    var hasher hash.Hash
+    if checksum != nil {
+        h, err := newHasher(checksum.Algorithm)
+        if err != nil {
+            return "", err
+        }
+        hasher = h
+        body = io.TeeReader(r, hasher)
+    }
+
+    var resp json.RawMessage
+    path := fmt.Sprintf("/nodes/%s/storage/%s/upload", node, storage)
+    if err := p.proxmoxClient.PostMultipart(ctx, path, &resp, fields, "filename", filename, body); err != nil {
+        return "", fmt.Errorf("failed to upload ISO %q to %s/%s: %w", filename, node, storage, err)
+    }
+
+    if hasher != nil {
+        if got := hex.EncodeToString(hasher.Sum(nil)); !strings.EqualFold(got, checksum.Value) {
+            // The bad file is already sitting on storage under iso.Name, so
+            // the next EnsureISO call would see it via storageHasISO and
+            // skip re-uploading it forever. Quarantine it so the caller can
+            // retry instead of getting stuck failing verifyExistingISO.
+            volid := fmt.Sprintf("%s:iso/%s", storage, filename)
+            if delErr := p.deleteStorageVolume(ctx, node, storage, volid); delErr != nil {
+                return "", fmt.Errorf("checksum mismatch for uploaded ISO %q (expected %s, got %s), and failed to remove the bad upload: %w", filename, checksum.Value, got, delErr)
+            }
+            return "", fmt.Errorf("checksum mismatch for uploaded ISO %q: expected %s, got %s (bad upload removed)", filename, checksum.Value, got)
+        }
+    }
+
+    var wrapper map[string]any
+    if err := json.Unmarshal(resp, &wrapper); err != nil {
+        return "", fmt.Errorf("failed to parse upload response: %w", err)
+    }
+
+    if d, ok := wrapper["data"]; ok {
+        switch v := d.(type) {
+        case string:
+            return v, nil
+        case map[string]any:
+            if upid, ok := v["upid"].(string); ok {
+                return upid, nil
+            }
+        }
+    }
+
+    return "", fmt.Errorf("unexpected upload response: %v", wrapper)
+}
+
+// ensureISOAvailable makes sure iso.Name exists on node/storage, downloading
+// or uploading it if not. It picks between startStorageDownload and
+// uploadISOFromFile based on which of SourceURL/LocalPath is set on iso.
+// The returned UPID is empty if the ISO was already present.
+func (p *Provisioner) ensureISOAvailable(ctx context.Context, node, storage string, iso ISOSpec) (string, error) {
+    present, err := p.storageHasISO(ctx, node, storage, iso.Name)
+    if err != nil {
+        return "", fmt.Errorf("failed to check for existing ISO %q: %w", iso.Name, err)
+    }
+    if present {
+        return "", nil
+    }
+
+    switch {
+    case iso.LocalPath != "":
+        f, err := openLocalISO(iso.LocalPath)
+        if err != nil {
+            return "", fmt.Errorf("failed to open local ISO %q: %w", iso.LocalPath, err)
+        }
+        defer f.Close()
+        return p.uploadISOFromFile(ctx, node, storage, iso.Name, f, iso.Checksum)
+    case iso.SourceURL != "":
+        return p.startStorageDownload(ctx, node, storage, iso.Name, iso.SourceURL, iso.Checksum)
+    default:
+        return "", fmt.Errorf("ISO spec for %q has neither SourceURL nor LocalPath set", iso.Name)
+    }
+}
+
+// openLocalISO opens a local ISO file for streaming to uploadISOFromFile.
+func openLocalISO(path string) (*os.File, error) {
+    return os.Open(path)
+}
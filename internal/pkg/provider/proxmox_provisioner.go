@@ -0,0 +1,41 @@
+package provider
+
+import (
+    "context"
+    "io"
+)
+
+// proxmoxClient is the subset of the Proxmox API client Provisioner needs:
+// plain JSON request/response calls, a streaming read for checksum
+// verification, and a multipart upload for local ISOs.
+type proxmoxClient interface {
+    Get(ctx context.Context, path string, target any) error
+    Post(ctx context.Context, path string, target any, params map[string]string) error
+    PostMultipart(ctx context.Context, path string, target any, fields map[string]string, fieldName, filename string, body io.Reader) error
+    GetStream(ctx context.Context, path string) (io.ReadCloser, error)
+    Delete(ctx context.Context, path string, target any) error
+}
+
+// Provisioner drives ISO provisioning against a Proxmox node: finding
+// ISO-capable storage, downloading or uploading ISOs, and verifying them
+// before a VM is booted from one.
+type Provisioner struct {
+    proxmoxClient proxmoxClient
+
+    // storageSelector picks which ISO-capable storage to use when a node
+    // has more than one. Defaults to FirstMatchSelector.
+    storageSelector StorageSelector
+}
+
+// NewProvisioner builds a Provisioner backed by client. selector chooses
+// which ISO-capable storage to use when a node has more than one; pass nil
+// to keep the previous behavior of picking whichever sorts first.
+func NewProvisioner(client proxmoxClient, selector StorageSelector) *Provisioner {
+    if selector == nil {
+        selector = FirstMatchSelector{}
+    }
+    return &Provisioner{
+        proxmoxClient:   client,
+        storageSelector: selector,
+    }
+}
@@ -0,0 +1,187 @@
+package provider
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+)
+
+// StorageInfo is the subset of a Proxmox /nodes/{node}/storage entry that
+// StorageSelector implementations need to make a decision.
+type StorageInfo struct {
+    Name    string
+    Content []string
+    Shared  bool
+    Type    string
+    Avail   int64
+    Total   int64
+}
+
+// hasISOContent reports whether the storage advertises "iso" content.
+func (s StorageInfo) hasISOContent() bool {
+    for _, c := range s.Content {
+        if c == "iso" {
+            return true
+        }
+    }
+    return false
+}
+
+// StorageSelector picks which storage to use for ISO downloads/uploads out
+// of the ISO-capable storages available on a node.
+type StorageSelector interface {
+    // SelectStorage returns the name of the storage to use among candidates,
+    // which contains only storages that already advertise "iso" content.
+    SelectStorage(candidates []StorageInfo) (string, error)
+}
+
+// FirstMatchSelector keeps the previous behavior of findISOStorageName:
+// whichever ISO-capable storage sorts first in the API response wins.
+type FirstMatchSelector struct{}
+
+func (FirstMatchSelector) SelectStorage(candidates []StorageInfo) (string, error) {
+    if len(candidates) == 0 {
+        return "", fmt.Errorf("no ISO-capable storage found")
+    }
+    return candidates[0].Name, nil
+}
+
+// PreferredNameSelector walks Preferred in order and picks the first name
+// that is among the candidates, falling back to the first ISO-capable
+// storage if none of the preferred names are present.
+type PreferredNameSelector struct {
+    Preferred []string
+}
+
+func (s PreferredNameSelector) SelectStorage(candidates []StorageInfo) (string, error) {
+    byName := make(map[string]StorageInfo, len(candidates))
+    for _, c := range candidates {
+        byName[c.Name] = c
+    }
+
+    for _, name := range s.Preferred {
+        if _, ok := byName[name]; ok {
+            return name, nil
+        }
+    }
+
+    return FirstMatchSelector{}.SelectStorage(candidates)
+}
+
+// SharedOnlySelector only considers storages marked shared: 1, so an ISO
+// uploaded once is visible cluster-wide rather than only on one node.
+type SharedOnlySelector struct{}
+
+func (SharedOnlySelector) SelectStorage(candidates []StorageInfo) (string, error) {
+    var shared []StorageInfo
+    for _, c := range candidates {
+        if c.Shared {
+            shared = append(shared, c)
+        }
+    }
+    if len(shared) == 0 {
+        return "", fmt.Errorf("no shared ISO-capable storage found")
+    }
+    return FirstMatchSelector{}.SelectStorage(shared)
+}
+
+// FreeSpaceSelector rejects storages with less than MinFree bytes available
+// after accounting for ExpectedISOSize, so provisioning doesn't pick a
+// storage that's too small to hold the ISO.
+type FreeSpaceSelector struct {
+    MinFree         int64
+    ExpectedISOSize int64
+}
+
+func (s FreeSpaceSelector) SelectStorage(candidates []StorageInfo) (string, error) {
+    var fits []StorageInfo
+    for _, c := range candidates {
+        if c.Avail-s.ExpectedISOSize >= s.MinFree {
+            fits = append(fits, c)
+        }
+    }
+    if len(fits) == 0 {
+        return "", fmt.Errorf("no ISO-capable storage with at least %d bytes free after a %d byte ISO", s.MinFree, s.ExpectedISOSize)
+    }
+    return FirstMatchSelector{}.SelectStorage(fits)
+}
+
+// TypeFilterSelector only considers storages whose "type" (e.g. "nfs",
+// "cephfs", "dir") is in Allowed.
+type TypeFilterSelector struct {
+    Allowed []string
+}
+
+func (s TypeFilterSelector) SelectStorage(candidates []StorageInfo) (string, error) {
+    allowed := make(map[string]bool, len(s.Allowed))
+    for _, t := range s.Allowed {
+        allowed[t] = true
+    }
+
+    var matched []StorageInfo
+    for _, c := range candidates {
+        if allowed[c.Type] {
+            matched = append(matched, c)
+        }
+    }
+    if len(matched) == 0 {
+        return "", fmt.Errorf("no ISO-capable storage of type %v found", s.Allowed)
+    }
+    return FirstMatchSelector{}.SelectStorage(matched)
+}
+
+// listISOStorages fetches all storages on node and returns the ones that
+// advertise "iso" content as StorageInfo, for use with a StorageSelector.
+func (p *Provisioner) listISOStorages(ctx context.Context, node string) ([]StorageInfo, error) {
+    var raw json.RawMessage
+    if err := p.proxmoxClient.Get(ctx, fmt.Sprintf("/nodes/%s/storage", node), &raw); err != nil {
+        return nil, err
+    }
+
+    var items []map[string]any
+    if err := json.Unmarshal(raw, &items); err != nil {
+        return nil, fmt.Errorf("failed to parse storage list: %w", err)
+    }
+
+    var infos []StorageInfo
+    for _, item := range items {
+        var name string
+        if s, ok := item["storage"].(string); ok {
+            name = s
+        } else if s, ok := item["name"].(string); ok {
+            name = s
+        }
+        if name == "" {
+            continue
+        }
+
+        info := StorageInfo{Name: name}
+        if content, ok := item["content"].([]any); ok {
+            for _, c := range content {
+                if cs, ok := c.(string); ok {
+                    info.Content = append(info.Content, cs)
+                }
+            }
+        }
+        if !info.hasISOContent() {
+            continue
+        }
+
+        if shared, ok := item["shared"].(float64); ok {
+            info.Shared = shared != 0
+        }
+        if typ, ok := item["type"].(string); ok {
+            info.Type = typ
+        }
+        if avail, ok := item["avail"].(float64); ok {
+            info.Avail = int64(avail)
+        }
+        if total, ok := item["total"].(float64); ok {
+            info.Total = int64(total)
+        }
+
+        infos = append(infos, info)
+    }
+
+    return infos, nil
+}
@@ -0,0 +1,149 @@
+package provider
+
+import "testing"
+
+func TestFirstMatchSelector(t *testing.T) {
+    tests := []struct {
+        name       string
+        candidates []StorageInfo
+        want       string
+        wantErr    bool
+    }{
+        {name: "no candidates", candidates: nil, wantErr: true},
+        {name: "single candidate", candidates: []StorageInfo{{Name: "local"}}, want: "local"},
+        {name: "picks first of several", candidates: []StorageInfo{{Name: "a"}, {Name: "b"}}, want: "a"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := (FirstMatchSelector{}).SelectStorage(tt.candidates)
+            checkSelectorResult(t, got, err, tt.want, tt.wantErr)
+        })
+    }
+}
+
+func TestPreferredNameSelector(t *testing.T) {
+    candidates := []StorageInfo{{Name: "local"}, {Name: "nfs-iso"}, {Name: "ceph-iso"}}
+
+    tests := []struct {
+        name      string
+        preferred []string
+        want      string
+        wantErr   bool
+    }{
+        {name: "picks preferred when present", preferred: []string{"ceph-iso", "nfs-iso"}, want: "ceph-iso"},
+        {name: "falls back to first match when no preferred name present", preferred: []string{"does-not-exist"}, want: "local"},
+        {name: "falls back on empty preferred list", preferred: nil, want: "local"},
+        {name: "falls back to error on no candidates", want: "", wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var cands []StorageInfo
+            if tt.name != "falls back to error on no candidates" {
+                cands = candidates
+            }
+            got, err := (PreferredNameSelector{Preferred: tt.preferred}).SelectStorage(cands)
+            checkSelectorResult(t, got, err, tt.want, tt.wantErr)
+        })
+    }
+}
+
+func TestSharedOnlySelector(t *testing.T) {
+    tests := []struct {
+        name       string
+        candidates []StorageInfo
+        want       string
+        wantErr    bool
+    }{
+        {name: "no candidates", wantErr: true},
+        {name: "no shared candidates", candidates: []StorageInfo{{Name: "local", Shared: false}}, wantErr: true},
+        {
+            name: "picks first shared",
+            candidates: []StorageInfo{
+                {Name: "local", Shared: false},
+                {Name: "nfs-iso", Shared: true},
+                {Name: "ceph-iso", Shared: true},
+            },
+            want: "nfs-iso",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := (SharedOnlySelector{}).SelectStorage(tt.candidates)
+            checkSelectorResult(t, got, err, tt.want, tt.wantErr)
+        })
+    }
+}
+
+func TestFreeSpaceSelector(t *testing.T) {
+    candidates := []StorageInfo{
+        {Name: "small", Avail: 1 << 30},   // 1 GiB
+        {Name: "big", Avail: 100 << 30},   // 100 GiB
+    }
+
+    tests := []struct {
+        name            string
+        minFree         int64
+        expectedISOSize int64
+        want            string
+        wantErr         bool
+    }{
+        {name: "both fit", minFree: 0, expectedISOSize: 0, want: "small"},
+        {name: "only big fits", minFree: 10 << 30, expectedISOSize: 0, want: "big"},
+        {name: "none fit", minFree: 1000 << 30, expectedISOSize: 0, wantErr: true},
+        {name: "expected ISO size eats into availability", minFree: 95 << 30, expectedISOSize: 10 << 30, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            sel := FreeSpaceSelector{MinFree: tt.minFree, ExpectedISOSize: tt.expectedISOSize}
+            got, err := sel.SelectStorage(candidates)
+            checkSelectorResult(t, got, err, tt.want, tt.wantErr)
+        })
+    }
+}
+
+func TestTypeFilterSelector(t *testing.T) {
+    candidates := []StorageInfo{
+        {Name: "local", Type: "dir"},
+        {Name: "nfs-iso", Type: "nfs"},
+        {Name: "ceph-iso", Type: "cephfs"},
+    }
+
+    tests := []struct {
+        name    string
+        allowed []string
+        want    string
+        wantErr bool
+    }{
+        {name: "matches one allowed type", allowed: []string{"nfs"}, want: "nfs-iso"},
+        {name: "matches first of several allowed types", allowed: []string{"nfs", "cephfs"}, want: "nfs-iso"},
+        {name: "no match", allowed: []string{"zfs"}, wantErr: true},
+        {name: "empty allowed list matches nothing", allowed: nil, wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := (TypeFilterSelector{Allowed: tt.allowed}).SelectStorage(candidates)
+            checkSelectorResult(t, got, err, tt.want, tt.wantErr)
+        })
+    }
+}
+
+func checkSelectorResult(t *testing.T, got string, err error, want string, wantErr bool) {
+    t.Helper()
+    if wantErr {
+        if err == nil {
+            t.Fatalf("SelectStorage() expected error, got result %q", got)
+        }
+        return
+    }
+    if err != nil {
+        t.Fatalf("SelectStorage() unexpected error: %v", err)
+    }
+    if got != want {
+        t.Errorf("SelectStorage() = %q, want %q", got, want)
+    }
+}
@@ -0,0 +1,217 @@
+package provider
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "time"
+)
+
+// LogLine is a single line emitted by a Proxmox task's log, as returned by
+// /nodes/{node}/tasks/{upid}/log.
+type LogLine struct {
+    Line int    `json:"n"`
+    Text string `json:"t"`
+}
+
+// WaitOptions configures TaskTracker.Wait's polling backoff.
+type WaitOptions struct {
+    // Initial is the delay before the first re-poll. Defaults to 1s.
+    Initial time.Duration
+
+    // Max caps the backoff delay between polls. Defaults to 30s.
+    Max time.Duration
+}
+
+func (o WaitOptions) withDefaults() WaitOptions {
+    if o.Initial <= 0 {
+        o.Initial = time.Second
+    }
+    if o.Max <= 0 {
+        o.Max = 30 * time.Second
+    }
+    return o
+}
+
+// TaskTracker polls the Proxmox task-status API to let callers block until a
+// long-running task (e.g. an ISO download started by startStorageDownload)
+// has finished.
+type TaskTracker struct {
+    client proxmoxClient
+}
+
+// NewTaskTracker builds a TaskTracker backed by the given Proxmox client.
+func NewTaskTracker(client proxmoxClient) *TaskTracker {
+    return &TaskTracker{client: client}
+}
+
+// Wait polls /nodes/{node}/tasks/{upid}/status on an exponential backoff
+// (starting at opts.Initial, capped at opts.Max) until the task's status is
+// "stopped". It returns an error if ctx is cancelled first, or if the task
+// stopped with an exitstatus other than "OK".
+func (t *TaskTracker) Wait(ctx context.Context, node, upid string, opts WaitOptions) error {
+    opts = opts.withDefaults()
+    delay := opts.Initial
+
+    for {
+        status, err := t.taskStatus(ctx, node, upid)
+        if err != nil {
+            return err
+        }
+
+        if status.Status == "stopped" {
+            if status.ExitStatus != "OK" {
+                return fmt.Errorf("task %s failed: %s", upid, status.ExitStatus)
+            }
+            return nil
+        }
+
+        select {
+        case <-ctx.Done():
+            return ctx.Err()
+        case <-time.After(delay):
+        }
+
+        delay *= 2
+        if delay > opts.Max {
+            delay = opts.Max
+        }
+    }
+}
+
+// taskStatusResult is the subset of /nodes/{node}/tasks/{upid}/status
+// TaskTracker needs to decide whether a task is done.
+type taskStatusResult struct {
+    Status     string
+    ExitStatus string
+}
+
+// taskStatus polls /nodes/{node}/tasks/{upid}/status once.
+func (t *TaskTracker) taskStatus(ctx context.Context, node, upid string) (taskStatusResult, error) {
+    var raw json.RawMessage
+    path := fmt.Sprintf("/nodes/%s/tasks/%s/status", node, upid)
+    if err := t.client.Get(ctx, path, &raw); err != nil {
+        return taskStatusResult{}, fmt.Errorf("failed to poll task %s status: %w", upid, err)
+    }
+
+    var wrapper struct {
+        Data struct {
+            Status     string `json:"status"`
+            ExitStatus string `json:"exitstatus"`
+        } `json:"data"`
+    }
+    if err := json.Unmarshal(raw, &wrapper); err != nil {
+        return taskStatusResult{}, fmt.Errorf("failed to parse task %s status: %w", upid, err)
+    }
+
+    return taskStatusResult{Status: wrapper.Data.Status, ExitStatus: wrapper.Data.ExitStatus}, nil
+}
+
+// Stream pages through /nodes/{node}/tasks/{upid}/log?start=N and emits any
+// new lines on the returned channel as they appear, so callers can surface
+// download progress. The channel is closed when ctx is cancelled, or once
+// the task has stopped and its log has been fully drained.
+func (t *TaskTracker) Stream(ctx context.Context, node, upid string) <-chan LogLine {
+    out := make(chan LogLine)
+
+    go func() {
+        defer close(out)
+        start := 0
+        path := fmt.Sprintf("/nodes/%s/tasks/%s/log", node, upid)
+
+        for {
+            var raw json.RawMessage
+            if err := t.client.Get(ctx, fmt.Sprintf("%s?start=%d", path, start), &raw); err != nil {
+                return
+            }
+
+            var wrapper struct {
+                Data []LogLine `json:"data"`
+            }
+            if err := json.Unmarshal(raw, &wrapper); err != nil {
+                return
+            }
+            lines := wrapper.Data
+
+            for _, l := range lines {
+                select {
+                case out <- l:
+                case <-ctx.Done():
+                    return
+                }
+                start = l.Line + 1
+            }
+
+            if len(lines) > 0 {
+                continue
+            }
+
+            // No new lines. Check whether the task has stopped before
+            // sleeping again, so Stream terminates on its own instead of
+            // polling forever for callers who don't cancel ctx.
+            status, err := t.taskStatus(ctx, node, upid)
+            if err != nil {
+                return
+            }
+            if status.Status == "stopped" {
+                return
+            }
+
+            select {
+            case <-ctx.Done():
+                return
+            case <-time.After(time.Second):
+            }
+        }
+    }()
+
+    return out
+}
+
+// EnsureISO finds ISO-capable storage on node, downloads or uploads iso if
+// it isn't already present, blocks until the resulting task finishes, and
+// verifies the checksum (if one is set on iso). It is the single
+// blocking entry point callers should use before booting a VM from an ISO.
+func (p *Provisioner) EnsureISO(ctx context.Context, node string, iso ISOSpec) error {
+    storage, err := p.findISOStorageName(ctx, node)
+    if err != nil {
+        return fmt.Errorf("failed to find ISO storage on node %s: %w", node, err)
+    }
+
+    upid, err := p.ensureISOAvailable(ctx, node, storage, iso)
+    if err != nil {
+        return fmt.Errorf("failed to ensure ISO %q is available: %w", iso.Name, err)
+    }
+
+    if upid != "" {
+        tracker := NewTaskTracker(p.proxmoxClient)
+
+        for line := range tracker.Stream(ctx, node, upid) {
+            log.Printf("proxmox: ISO %q task %s: %s", iso.Name, upid, line.Text)
+        }
+
+        if err := tracker.Wait(ctx, node, upid, WaitOptions{}); err != nil {
+            return fmt.Errorf("failed waiting for ISO %q task: %w", iso.Name, err)
+        }
+    }
+
+    if iso.Checksum != nil {
+        ok, err := p.verifyExistingISO(ctx, node, storage, iso.Name, *iso.Checksum)
+        if err != nil {
+            return fmt.Errorf("failed to verify checksum for ISO %q: %w", iso.Name, err)
+        }
+        if !ok {
+            // The cached ISO is corrupt. Quarantine it so a retried
+            // EnsureISO call re-downloads/re-uploads it instead of
+            // storageHasISO finding the same bad file "present" forever.
+            volid := fmt.Sprintf("%s:iso/%s", storage, iso.Name)
+            if delErr := p.deleteStorageVolume(ctx, node, storage, volid); delErr != nil {
+                return fmt.Errorf("checksum mismatch for ISO %q on %s/%s, and failed to remove the bad cached copy: %w", iso.Name, node, storage, delErr)
+            }
+            return fmt.Errorf("checksum mismatch for ISO %q on %s/%s (bad cached copy removed)", iso.Name, node, storage)
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,173 @@
+package provider
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "strings"
+    "testing"
+    "time"
+)
+
+// fakeLogLine is the wire shape of a single Proxmox task-log entry, used to
+// build canned /log responses ("n"/"t" keys, matching LogLine's json tags).
+type fakeLogLine struct {
+    N int
+    T string
+}
+
+// fakeTaskClient is a minimal proxmoxClient that answers status/log polls
+// from a canned script, for testing TaskTracker without a real Proxmox API.
+type fakeTaskClient struct {
+    statuses   []string // one status per call to the .../status endpoint; last one repeats
+    exitStatus string
+
+    logPages [][]fakeLogLine // one page per call to the .../log endpoint; further calls get no lines
+
+    statusCalls int
+    logCalls    int
+}
+
+func (f *fakeTaskClient) Get(ctx context.Context, path string, target any) error {
+    if strings.Contains(path, "/log") {
+        return f.getLog(target)
+    }
+    return f.getStatus(target)
+}
+
+func (f *fakeTaskClient) getStatus(target any) error {
+    var status string
+    idx := f.statusCalls
+    if idx >= len(f.statuses) {
+        idx = len(f.statuses) - 1
+    }
+    status = f.statuses[idx]
+    f.statusCalls++
+
+    exitStatus := ""
+    if status == "stopped" {
+        exitStatus = f.exitStatus
+    }
+
+    body := fmt.Sprintf(`{"data":{"status":%q,"exitstatus":%q}}`, status, exitStatus)
+    return json.Unmarshal([]byte(body), target)
+}
+
+func (f *fakeTaskClient) getLog(target any) error {
+    var page []fakeLogLine
+    if f.logCalls < len(f.logPages) {
+        page = f.logPages[f.logCalls]
+    }
+    f.logCalls++
+
+    lines := make([]string, 0, len(page))
+    for _, l := range page {
+        lines = append(lines, fmt.Sprintf(`{"n":%d,"t":%q}`, l.N, l.T))
+    }
+    body := fmt.Sprintf(`{"data":[%s]}`, strings.Join(lines, ","))
+    return json.Unmarshal([]byte(body), target)
+}
+
+func (f *fakeTaskClient) Post(ctx context.Context, path string, target any, params map[string]string) error {
+    return fmt.Errorf("unexpected Post call")
+}
+
+func (f *fakeTaskClient) PostMultipart(ctx context.Context, path string, target any, fields map[string]string, fieldName, filename string, body io.Reader) error {
+    return fmt.Errorf("unexpected PostMultipart call")
+}
+
+func (f *fakeTaskClient) GetStream(ctx context.Context, path string) (io.ReadCloser, error) {
+    return nil, fmt.Errorf("unexpected GetStream call")
+}
+
+func (f *fakeTaskClient) Delete(ctx context.Context, path string, target any) error {
+    return fmt.Errorf("unexpected Delete call")
+}
+
+func TestTaskTrackerWait(t *testing.T) {
+    tests := []struct {
+        name       string
+        statuses   []string
+        exitStatus string
+        wantErr    bool
+    }{
+        {name: "succeeds when stopped OK", statuses: []string{"running", "running", "stopped"}, exitStatus: "OK"},
+        {name: "fails when stopped with non-OK exitstatus", statuses: []string{"stopped"}, exitStatus: "some error", wantErr: true},
+        {name: "stops immediately if already stopped", statuses: []string{"stopped"}, exitStatus: "OK"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            client := &fakeTaskClient{statuses: tt.statuses, exitStatus: tt.exitStatus}
+            tracker := NewTaskTracker(client)
+
+            err := tracker.Wait(context.Background(), "pve", "UPID:test", WaitOptions{Initial: time.Millisecond, Max: time.Millisecond})
+            if tt.wantErr && err == nil {
+                t.Fatalf("Wait() expected error, got nil")
+            }
+            if !tt.wantErr && err != nil {
+                t.Fatalf("Wait() unexpected error: %v", err)
+            }
+        })
+    }
+}
+
+func TestTaskTrackerWaitContextCancelled(t *testing.T) {
+    client := &fakeTaskClient{statuses: []string{"running"}}
+    tracker := NewTaskTracker(client)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+
+    err := tracker.Wait(ctx, "pve", "UPID:test", WaitOptions{Initial: time.Hour})
+    if err == nil {
+        t.Fatal("Wait() expected error from cancelled context, got nil")
+    }
+}
+
+func TestTaskTrackerStream(t *testing.T) {
+    client := &fakeTaskClient{
+        logPages: [][]fakeLogLine{
+            {{N: 1, T: "starting download"}, {N: 2, T: "50% complete"}},
+            {}, // empty page triggers the stopped check below
+        },
+        statuses: []string{"stopped"},
+    }
+    tracker := NewTaskTracker(client)
+
+    var got []LogLine
+    for line := range tracker.Stream(context.Background(), "pve", "UPID:test") {
+        got = append(got, line)
+    }
+
+    want := []LogLine{
+        {Line: 1, Text: "starting download"},
+        {Line: 2, Text: "50% complete"},
+    }
+    if len(got) != len(want) {
+        t.Fatalf("Stream() delivered %d lines, want %d: %+v", len(got), len(want), got)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Errorf("Stream() line %d = %+v, want %+v", i, got[i], want[i])
+        }
+    }
+}
+
+func TestTaskTrackerStreamContextCancelled(t *testing.T) {
+    client := &fakeTaskClient{
+        logPages: [][]fakeLogLine{{}},
+        statuses: []string{"running"},
+    }
+    tracker := NewTaskTracker(client)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+
+    ch := tracker.Stream(ctx, "pve", "UPID:test")
+    for range ch {
+    }
+    // Channel must close on a cancelled context even though the task never
+    // stops, or this would hang the test.
+}